@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/Nelwhix/todo"
+)
+
+// batchOp is one operation in a POST /todo/batch request body. Id is
+// resolved against the list as it stood before the batch started (see
+// idTracker), so an earlier "delete" in the same batch never shifts what
+// a later op targets. Version is optional; if the caller doesn't know
+// it, the op is applied against whatever version is current at the time
+// applyBatch reaches it (a blind write, same as any other unversioned
+// client), rather than rejected.
+type batchOp struct {
+	Op      string `json:"op"`
+	ID      int    `json:"id"`
+	Task    string `json:"task"`
+	Version int    `json:"version,omitempty"`
+}
+
+// batchOpResult reports what happened to one batchOp, in request order.
+// A failed op (not found, version mismatch, unknown op) does not abort
+// the batch or roll back ops already applied earlier in the same batch --
+// only Error is set, OK is false, and applyBatch moves on to the next op.
+type batchOpResult struct {
+	Op    string `json:"op"`
+	ID    int    `json:"id,omitempty"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// pendingEvent is a broker publish deferred until after a batch's save
+// succeeds, so a subscriber never sees an event for a write that turned
+// out not to be persisted.
+type pendingEvent struct {
+	eventType eventType
+	item      todo.Item
+}
+
+// idTracker maps a batch op's original id (its position in the list
+// before the batch began) to its current position, updated as earlier
+// ops in the same batch delete items and shift everything after them
+// down by one. Without this, "delete id 1" followed by "delete id 2"
+// would delete positions 1 and 2 after the first delete has already
+// shifted the second target down to position 1.
+type idTracker struct {
+	aliveOriginalIDs []int // in current list order
+	nextOriginalID   int
+}
+
+func newIDTracker(count int) *idTracker {
+	alive := make([]int, count)
+	for i := range alive {
+		alive[i] = i + 1
+	}
+	return &idTracker{aliveOriginalIDs: alive, nextOriginalID: count + 1}
+}
+
+// resolve returns originalID's current position, or false if it's
+// already been deleted (or never existed) in this batch.
+func (t *idTracker) resolve(originalID int) (int, bool) {
+	for i, id := range t.aliveOriginalIDs {
+		if id == originalID {
+			return i + 1, true
+		}
+	}
+	return 0, false
+}
+
+// added records a successful "add", returning its original id for the
+// batchOpResult (and so later ops in the same batch could target it via
+// the id the response handed back, same as a real round-trip would).
+func (t *idTracker) added() int {
+	id := t.nextOriginalID
+	t.nextOriginalID++
+	t.aliveOriginalIDs = append(t.aliveOriginalIDs, id)
+	return id
+}
+
+// deleted removes originalID from the alive set, shifting every id after
+// it in list order down to match todo.List.Delete's own renumbering.
+func (t *idTracker) deleted(originalID int) {
+	for i, id := range t.aliveOriginalIDs {
+		if id == originalID {
+			t.aliveOriginalIDs = append(t.aliveOriginalIDs[:i], t.aliveOriginalIDs[i+1:]...)
+			return
+		}
+	}
+}
+
+// batchHandler serves POST /todo/batch. The whole batch is applied as one
+// load/mutate/save transaction (see applyBatch), so it is atomic from the
+// point of view of any other request reading or writing todoFile -- no
+// other request's read or write can land between two ops in the same
+// batch. That is separate from per-op success: one op failing does not
+// rewind ops already applied earlier in the same batch (see
+// batchOpResult).
+func (s *server) batchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		replyWithError(w, http.StatusMethodNotAllowed)
+		return
+	}
+
+	var ops []batchOp
+	if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+		replyWithError(w, http.StatusBadRequest)
+		return
+	}
+
+	dt := s.deadline(r)
+	defer dt.Cancel()
+
+	results, events, err := applyBatch(s.todoFile, s.format, dt.Done(), ops)
+	if writeStoreErr(w, err) {
+		return
+	}
+
+	for _, ev := range events {
+		s.broker.publish(ev.eventType, ev.item)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(struct {
+		Results []batchOpResult `json:"results"`
+	}{results}); err != nil {
+		replyWithError(w, http.StatusInternalServerError)
+	}
+}
+
+// applyBatch holds path's file lock for the full load/mutate/save cycle,
+// so the batch is one atomic unit as far as any other request against the
+// same file is concerned. Within that transaction, each op is applied to
+// the in-memory list and version table in turn; an op that fails is
+// recorded in its batchOpResult and skipped, leaving every other op in
+// the batch unaffected. The mutated list and version table are saved
+// once, after every op has been attempted, and events for the ops that
+// succeeded are returned for the caller to publish once the save is
+// confirmed.
+func applyBatch(path string, format storageFormat, done <-chan struct{}, ops []batchOp) ([]batchOpResult, []pendingEvent, error) {
+	mu := lockFor(path)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if aborted(done) {
+		return nil, nil, errDeadlineExceeded
+	}
+
+	l, err := getTodoList(path, format)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vt, err := loadVersionTable(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tracker := newIDTracker(len(l))
+	results := make([]batchOpResult, len(ops))
+	var events []pendingEvent
+
+	for i, op := range ops {
+		id, ev, err := applyBatchOp(&l, &vt, tracker, op)
+		results[i] = batchOpResult{Op: op.Op, ID: id, OK: err == nil}
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	if aborted(done) {
+		return nil, nil, errDeadlineExceeded
+	}
+
+	if err := saveTodoList(path, format, l); err != nil {
+		return nil, nil, err
+	}
+	if err := saveVersionTable(path, vt); err != nil {
+		return nil, nil, err
+	}
+
+	return results, events, nil
+}
+
+// applyBatchOp resolves op's id through tracker and applies it to l and
+// vt in place, returning the event to publish once the caller's save
+// succeeds. It returns the id to report back to the caller (the original
+// id for existing items, the newly assigned original id for "add").
+func applyBatchOp(l *todo.List, vt *versionTable, tracker *idTracker, op batchOp) (int, pendingEvent, error) {
+	if op.Op == "add" {
+		l.Add(op.Task)
+		currentID := len(*l)
+		originalID := tracker.added()
+		vt.bump(currentID)
+
+		return originalID, pendingEvent{eventCreated, (*l)[currentID-1]}, nil
+	}
+
+	currentID, ok := tracker.resolve(op.ID)
+	if !ok {
+		return op.ID, pendingEvent{}, fmt.Errorf("no item with id %d", op.ID)
+	}
+
+	expectedVersion := vt.versionFor(currentID)
+	if op.Version != 0 && op.Version != expectedVersion {
+		return op.ID, pendingEvent{}, errVersionMismatch
+	}
+
+	switch op.Op {
+	case "complete":
+		if err := l.Complete(currentID); err != nil {
+			return op.ID, pendingEvent{}, err
+		}
+		vt.bump(currentID)
+		return op.ID, pendingEvent{eventCompleted, (*l)[currentID-1]}, nil
+
+	case "delete":
+		item := (*l)[currentID-1]
+		if err := l.Delete(currentID); err != nil {
+			return op.ID, pendingEvent{}, err
+		}
+		vt.deleteID(currentID)
+		tracker.deleted(op.ID)
+		return op.ID, pendingEvent{eventDeleted, item}, nil
+
+	case "update":
+		item := (*l)[currentID-1]
+		item.Task = op.Task
+		(*l)[currentID-1] = item
+		vt.bump(currentID)
+		return op.ID, pendingEvent{eventUpdated, item}, nil
+
+	default:
+		return op.ID, pendingEvent{}, fmt.Errorf("unknown op %q", op.Op)
+	}
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	t.Run("it applies multiple ops atomically", func(t *testing.T) {
+		ops := []batchOp{
+			{Op: "add", Task: "Task Number 3."},
+			{Op: "complete", ID: 1},
+			{Op: "delete", ID: 2},
+		}
+
+		var body bytes.Buffer
+		if err := json.NewEncoder(&body).Encode(ops); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := http.Post(url+"/todo/batch", "application/json", &body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+
+		if r.StatusCode != http.StatusOK {
+			t.Fatalf("Expected %q, got %q.", http.StatusText(http.StatusOK), http.StatusText(r.StatusCode))
+		}
+
+		var resp struct {
+			Results []batchOpResult `json:"results"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, result := range resp.Results {
+			if !result.OK {
+				t.Errorf("Expected op %q to succeed, got error %q.", result.Op, result.Error)
+			}
+		}
+	})
+
+	t.Run("an unknown op fails without aborting the batch", func(t *testing.T) {
+		ops := []batchOp{
+			{Op: "bogus", ID: 1},
+			{Op: "complete", ID: 2},
+		}
+
+		var body bytes.Buffer
+		if err := json.NewEncoder(&body).Encode(ops); err != nil {
+			t.Fatal(err)
+		}
+
+		r, err := http.Post(url+"/todo/batch", "application/json", &body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer r.Body.Close()
+
+		if r.StatusCode != http.StatusOK {
+			t.Fatalf("Expected %q, got %q.", http.StatusText(http.StatusOK), http.StatusText(r.StatusCode))
+		}
+
+		var resp struct {
+			Results []batchOpResult `json:"results"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+
+		if resp.Results[0].OK {
+			t.Error("Expected the unknown op to be reported as failed.")
+		}
+		if !resp.Results[1].OK {
+			t.Errorf("Expected the op after the unknown one to succeed, got error %q.", resp.Results[1].Error)
+		}
+	})
+}
+
+func TestPatchMergePatchBody(t *testing.T) {
+	url, cleanup := setupAPI(t)
+	defer cleanup()
+
+	etagVal := currentETag(t, url, 1)
+
+	body, err := json.Marshal(map[string]any{"task": "Renamed task"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, err := http.NewRequest(http.MethodPatch, url+"/todo/1", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("If-Match", etagVal)
+
+	r, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode != http.StatusNoContent {
+		t.Fatalf("Expected %q, got %q.", http.StatusText(http.StatusNoContent), http.StatusText(r.StatusCode))
+	}
+
+	getResp, err := http.Get(url + "/todo/1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer getResp.Body.Close()
+
+	var resp todoResponse
+	if err := json.NewDecoder(getResp.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Results[0].Task != "Renamed task" {
+		t.Errorf("Expected task to be renamed, got %q.", resp.Results[0].Task)
+	}
+}
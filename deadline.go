@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errDeadlineExceeded is returned by a Store call that noticed its
+// deadlineTimer fire mid-operation.
+var errDeadlineExceeded = errors.New("store: deadline exceeded")
+
+// deadlineTimer is a shared, closable "done" channel for one in-flight
+// operation, modeled on the netstack deadlineTimer pattern: the channel
+// starts open, is closed once when the deadline elapses (or the caller
+// aborts early), and any number of goroutines can select on Done() to
+// notice without a wake-up race. Unlike context.Context, callers that
+// don't need cancellation values can ignore this and nothing further
+// needs to be threaded through; Store implementations that do long scans
+// select on it between steps to abort mid-write.
+type deadlineTimer struct {
+	mu     sync.Mutex
+	done   chan struct{}
+	timer  *time.Timer
+	closed bool
+}
+
+// newDeadlineTimer returns a deadlineTimer whose Done channel closes after
+// d. A non-positive d means no deadline; Done never closes on its own.
+func newDeadlineTimer(d time.Duration) *deadlineTimer {
+	dt := &deadlineTimer{done: make(chan struct{})}
+
+	if d > 0 {
+		dt.timer = time.AfterFunc(d, dt.cancel)
+	}
+
+	return dt
+}
+
+// Done returns the channel that closes when the deadline elapses or
+// Cancel is called.
+func (dt *deadlineTimer) Done() <-chan struct{} {
+	return dt.done
+}
+
+// Cancel stops the timer and closes Done early, e.g. when the request
+// that owns this deadline finishes normally.
+func (dt *deadlineTimer) Cancel() {
+	if dt.timer != nil {
+		dt.timer.Stop()
+	}
+	dt.cancel()
+}
+
+func (dt *deadlineTimer) cancel() {
+	dt.mu.Lock()
+	defer dt.mu.Unlock()
+
+	if !dt.closed {
+		dt.closed = true
+		close(dt.done)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDeadlineTimerFiresAfterDuration(t *testing.T) {
+	dt := newDeadlineTimer(10 * time.Millisecond)
+
+	select {
+	case <-dt.Done():
+		t.Fatal("Expected Done to stay open before the deadline elapses.")
+	default:
+	}
+
+	select {
+	case <-dt.Done():
+	case <-time.After(time.Second):
+		t.Fatal("Expected Done to close once the deadline elapsed.")
+	}
+}
+
+func TestDeadlineTimerCancel(t *testing.T) {
+	dt := newDeadlineTimer(time.Hour)
+	dt.Cancel()
+
+	select {
+	case <-dt.Done():
+	default:
+		t.Fatal("Expected Done to close immediately after Cancel.")
+	}
+}
+
+func TestDeadlineTimerNoDeadline(t *testing.T) {
+	dt := newDeadlineTimer(0)
+
+	select {
+	case <-dt.Done():
+		t.Fatal("Expected Done to stay open with a zero deadline.")
+	default:
+	}
+}
@@ -0,0 +1,399 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nelwhix/todo"
+)
+
+type todoResponse struct {
+	Results      []todoResponseItem `json:"results"`
+	Date         int64              `json:"date"`
+	TotalResults int                `json:"total_results"`
+	NextCursor   string             `json:"next_cursor,omitempty"`
+}
+
+// server holds everything the HTTP handlers need to serve one todo file:
+// where it lives and in what format, the event broker for /todo/stream,
+// and how long a single request is allowed to run before its store calls
+// are asked to abort.
+type server struct {
+	todoFile       string
+	format         storageFormat
+	broker         *todoBroker
+	requestTimeout time.Duration
+}
+
+func newMux(todoFile string) http.Handler {
+	return newMuxFormat(todoFile, formatJSON)
+}
+
+// newMuxFormat is newMux with an explicit on-disk storage format, so the
+// server can be pointed at a todo.txt file via the -format flag. There is
+// no per-request timeout.
+func newMuxFormat(todoFile string, format storageFormat) http.Handler {
+	return newServerMux(&server{todoFile: todoFile, format: format, broker: newTodoBroker()})
+}
+
+// newServerMux is newMuxFormat for a fully configured server, used by main
+// to also set a request timeout.
+func newServerMux(s *server) http.Handler {
+	m := http.NewServeMux()
+
+	m.HandleFunc("/", rootHandler)
+	m.HandleFunc("/todo", s.todoHandler)
+	m.HandleFunc("/todo/stream", streamHandler(s.broker))
+	m.HandleFunc("/todo/batch", s.batchHandler)
+	m.HandleFunc("/todo/", s.todoItemHandler)
+
+	return m
+}
+
+func (s *server) store() Store {
+	return newFileStore(s.todoFile, s.format)
+}
+
+// deadline starts a deadlineTimer for one request: it fires after
+// s.requestTimeout (no-op when zero) and also fires early if the request
+// itself is cancelled, e.g. the client disconnects or the server begins
+// a graceful shutdown.
+func (s *server) deadline(r *http.Request) *deadlineTimer {
+	dt := newDeadlineTimer(s.requestTimeout)
+
+	go func() {
+		select {
+		case <-r.Context().Done():
+			dt.Cancel()
+		case <-dt.Done():
+		}
+	}()
+
+	return dt
+}
+
+func rootHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		replyWithError(w, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	fmt.Fprintln(w, "There's an API here")
+}
+
+func (s *server) todoHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.getAllTodos(w, r)
+	case http.MethodPost:
+		s.addTodo(w, r)
+	default:
+		replyWithError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *server) todoItemHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/todo/"))
+	if err != nil {
+		replyWithError(w, http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.getTodo(w, r, id)
+	case http.MethodDelete:
+		s.deleteTodo(w, r, id)
+	case http.MethodPatch:
+		s.patchTodo(w, r, id)
+	default:
+		replyWithError(w, http.StatusMethodNotAllowed)
+	}
+}
+
+// parseTodoTxtFilters reads the project/context/priority/due_before query
+// parameters shared by the todo.txt-aware GET /todo endpoint.
+func parseTodoTxtFilters(r *http.Request) todoTxtFilters {
+	q := r.URL.Query()
+
+	f := todoTxtFilters{
+		project:  q.Get("project"),
+		context:  q.Get("context"),
+		priority: q.Get("priority"),
+	}
+
+	if raw := q.Get("due_before"); raw != "" {
+		if due, err := time.Parse("2006-01-02", raw); err == nil {
+			f.dueBefore = &due
+		}
+	}
+
+	return f
+}
+
+func (s *server) getAllTodos(w http.ResponseWriter, r *http.Request) {
+	dt := s.deadline(r)
+	defer dt.Cancel()
+
+	l, err := s.store().List(dt.Done())
+	if errors.Is(err, errDeadlineExceeded) {
+		replyWithError(w, http.StatusGatewayTimeout)
+		return
+	} else if err != nil {
+		replyWithError(w, http.StatusInternalServerError)
+		return
+	}
+
+	l = parseTodoTxtFilters(r).apply(l)
+
+	lq := parseListQuery(r)
+	l = filterAndSort(l, lq)
+	total := len(l)
+
+	results, nextCursor := page(l, lq)
+	setNextLink(w, r, nextCursor)
+
+	replyTodoListPage(w, results, total, nextCursor)
+}
+
+func (s *server) getTodo(w http.ResponseWriter, r *http.Request, id int) {
+	dt := s.deadline(r)
+	defer dt.Cancel()
+
+	item, version, err := s.store().Get(dt.Done(), id)
+	switch {
+	case errors.Is(err, errNotFound):
+		replyWithError(w, http.StatusNotFound)
+		return
+	case errors.Is(err, errDeadlineExceeded):
+		replyWithError(w, http.StatusGatewayTimeout)
+		return
+	case err != nil:
+		replyWithError(w, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("ETag", etag(version))
+	replyTodoList(w, todo.List{item})
+}
+
+// writeStoreErr replies with the HTTP status matching a Store error and
+// reports whether it did, so callers can `if writeStoreErr(w, err) { return }`.
+// A nil err is a no-op that reports false.
+func writeStoreErr(w http.ResponseWriter, err error) bool {
+	switch {
+	case errors.Is(err, errNotFound):
+		replyWithError(w, http.StatusNotFound)
+	case errors.Is(err, errVersionMismatch):
+		replyWithError(w, http.StatusPreconditionFailed)
+	case errors.Is(err, errDeadlineExceeded):
+		replyWithError(w, http.StatusGatewayTimeout)
+	case err != nil:
+		replyWithError(w, http.StatusInternalServerError)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// ifMatchVersion reads and parses the If-Match header required by writes
+// that target a single item. ok is false when the header is missing or
+// unparsable, in which case the caller should reply 400.
+func ifMatchVersion(r *http.Request) (version int, ok bool) {
+	raw := r.Header.Get("If-Match")
+	if raw == "" {
+		return 0, false
+	}
+
+	version, err := parseETag(raw)
+	return version, err == nil
+}
+
+func (s *server) addTodo(w http.ResponseWriter, r *http.Request) {
+	var item struct {
+		Task string `json:"task"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		replyWithError(w, http.StatusBadRequest)
+		return
+	}
+
+	dt := s.deadline(r)
+	defer dt.Cancel()
+
+	id, version, err := s.store().Add(dt.Done(), item.Task)
+	if errors.Is(err, errDeadlineExceeded) {
+		replyWithError(w, http.StatusGatewayTimeout)
+		return
+	} else if err != nil {
+		replyWithError(w, http.StatusInternalServerError)
+		return
+	}
+
+	added, _, err := s.store().Get(dt.Done(), id)
+	if err == nil {
+		s.broker.publish(eventCreated, added)
+	}
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *server) deleteTodo(w http.ResponseWriter, r *http.Request, id int) {
+	dt := s.deadline(r)
+	defer dt.Cancel()
+
+	store := s.store()
+
+	item, _, err := store.Get(dt.Done(), id)
+	if writeStoreErr(w, err) {
+		return
+	}
+
+	ifMatch, ok := ifMatchVersion(r)
+	if !ok {
+		replyWithError(w, http.StatusBadRequest)
+		return
+	}
+
+	if writeStoreErr(w, store.Delete(dt.Done(), id, ifMatch)) {
+		return
+	}
+
+	s.broker.publish(eventDeleted, item)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// todoMergePatch is a JSON Merge Patch (RFC 7396) body for PATCH
+// /todo/{id}: any field present is applied, absent fields are left alone.
+// This is in addition to the original "?complete" query flag, which
+// remains the shorthand for {"done": true}.
+type todoMergePatch struct {
+	Task *string `json:"task"`
+	Done *bool   `json:"done"`
+}
+
+func (s *server) patchTodo(w http.ResponseWriter, r *http.Request, id int) {
+	patch, hasBody, err := decodeMergePatch(r)
+	if err != nil {
+		replyWithError(w, http.StatusBadRequest)
+		return
+	}
+
+	_, completeFlag := r.URL.Query()["complete"]
+	if !hasBody && !completeFlag {
+		replyWithError(w, http.StatusBadRequest)
+		return
+	}
+
+	dt := s.deadline(r)
+	defer dt.Cancel()
+
+	store := s.store()
+
+	item, version, err := store.Get(dt.Done(), id)
+	if writeStoreErr(w, err) {
+		return
+	}
+
+	ifMatch, ok := ifMatchVersion(r)
+	if !ok {
+		replyWithError(w, http.StatusBadRequest)
+		return
+	}
+	if ifMatch != version {
+		replyWithError(w, http.StatusPreconditionFailed)
+		return
+	}
+
+	event := eventUpdated
+
+	// A rename is its own Update call so it composes with completion
+	// below without clobbering whatever Complete() stamps.
+	if patch.Task != nil {
+		item.Task = *patch.Task
+		version, err = store.Update(dt.Done(), id, version, item)
+		if writeStoreErr(w, err) {
+			return
+		}
+	}
+
+	completing := completeFlag || (patch.Done != nil && *patch.Done)
+	uncompleting := patch.Done != nil && !*patch.Done
+
+	switch {
+	case completing:
+		// Goes through the same todo.List.Complete path the batch
+		// handler uses, so CompletedAt gets stamped instead of a raw
+		// Done = true field assignment.
+		item, version, err = store.Complete(dt.Done(), id, version)
+		if writeStoreErr(w, err) {
+			return
+		}
+		event = eventCompleted
+
+	case uncompleting:
+		// todo.List has no "uncomplete" method to mirror; this is a
+		// plain field assignment through Update.
+		item.Done = false
+		version, err = store.Update(dt.Done(), id, version, item)
+		if writeStoreErr(w, err) {
+			return
+		}
+	}
+
+	s.broker.publish(event, item)
+
+	w.Header().Set("ETag", etag(version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeMergePatch reads a JSON merge patch body, if any. hasBody is
+// false (with a zero patch and nil error) when the request had no body,
+// so callers can fall back to the "?complete" query flag.
+func decodeMergePatch(r *http.Request) (patch todoMergePatch, hasBody bool, err error) {
+	if r.ContentLength == 0 {
+		return todoMergePatch{}, false, nil
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		return todoMergePatch{}, false, err
+	}
+
+	return patch, true, nil
+}
+
+func replyTodoList(w http.ResponseWriter, l todo.List) {
+	replyTodoListPage(w, l, len(l), "")
+}
+
+// replyTodoListPage is replyTodoList for a GET /todo response that has
+// been filtered, sorted and paginated: total is the count across every
+// matching item (not just this page), and nextCursor is set whenever
+// more results remain.
+func replyTodoListPage(w http.ResponseWriter, l todo.List, total int, nextCursor string) {
+	resp := todoResponse{
+		Results:      toResponseItems(l),
+		Date:         time.Now().Unix(),
+		TotalResults: total,
+		NextCursor:   nextCursor,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		replyWithError(w, http.StatusInternalServerError)
+	}
+}
+
+func replyWithError(w http.ResponseWriter, code int) {
+	http.Error(w, http.StatusText(code), code)
+}
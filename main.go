@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 )
 
@@ -21,19 +25,65 @@ func main() {
 
 	host := flag.String("h", "localhost", "Server host")
 	port := flag.Int("p", 8888, "Server port")
-	todoFile := flag.String("f", "todoServer.json", "todo JSON file")
+	todoFile := flag.String("f", "todoServer.json", "todo file")
+	format := flag.String("format", string(formatJSON), "todo file format: json|todotxt")
+	store := flag.String("store", "file", "storage backend: file (boltdb and sqlite3 are only placeholder comments in store_bolt.go/store_sqlite.go and are not available in this build)")
+	requestTimeout := flag.Duration("request-timeout", 5*time.Second, "per-request deadline for store operations; 0 disables it")
+	shutdownGrace := flag.Duration("shutdown-grace", 10*time.Second, "how long to wait for in-flight requests on shutdown")
 	flag.Parse()
 
+	if *format != string(formatJSON) && *format != string(formatTodoTxt) {
+		fmt.Fprintf(os.Stderr, "invalid -format %q: must be %q or %q\n", *format, formatJSON, formatTodoTxt)
+		os.Exit(1)
+	}
+
+	if *store != "file" {
+		fmt.Fprintf(os.Stderr, "invalid -store %q: only \"file\" is available in this build\n", *store)
+		os.Exit(1)
+	}
+
+	todoServer := &server{
+		todoFile:       *todoFile,
+		format:         storageFormat(*format),
+		broker:         newTodoBroker(),
+		requestTimeout: *requestTimeout,
+	}
+
 	s := &http.Server{
 		Addr: fmt.Sprintf("%s:%d", *host, *port),
-		Handler: newMux(*todoFile),
+		Handler: newServerMux(todoServer),
 		ReadTimeout: 10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 	}
 
-	log.Printf("Local server starting on port %v", *port)
-	if err := s.ListenAndServe(); err != nil {
-		fmt.Fprintln(os.Stderr, err)
-		os.Exit(1)
+	done := make(chan error, 1)
+	go func() {
+		log.Printf("Local server starting on port %v", *port)
+		if err := s.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			done <- err
+			return
+		}
+		done <- nil
+	}()
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case <-sig:
+		log.Printf("Shutting down, waiting up to %v for in-flight requests", *shutdownGrace)
+
+		ctx, cancel := context.WithTimeout(context.Background(), *shutdownGrace)
+		defer cancel()
+
+		if err := s.Shutdown(ctx); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
 	}
 }
\ No newline at end of file
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Nelwhix/todo"
+)
+
+// defaultPageLimit is used when a request sets no valid ?limit= (with or
+// without ?cursor=), keeping GET /todo bounded even for a large todo file.
+const defaultPageLimit = 100
+
+// listQuery is the done/q/sort/limit/offset query parameters GET /todo
+// accepts on top of the todo.txt filters in todotxt.go.
+type listQuery struct {
+	done   *bool
+	q      string
+	sort   string
+	limit  int
+	offset int
+}
+
+// parseListQuery reads ?done=, ?q=, ?sort=, ?limit=, ?offset= and ?cursor=
+// (?cursor= is an opaque, base64-encoded offset and takes precedence over
+// ?offset= when both are set).
+func parseListQuery(r *http.Request) listQuery {
+	q := r.URL.Query()
+
+	lq := listQuery{
+		q:     q.Get("q"),
+		sort:  q.Get("sort"),
+		limit: defaultPageLimit,
+	}
+
+	if raw := q.Get("done"); raw != "" {
+		if done, err := strconv.ParseBool(raw); err == nil {
+			lq.done = &done
+		}
+	}
+
+	if raw := q.Get("limit"); raw != "" {
+		if limit, err := strconv.Atoi(raw); err == nil && limit >= 0 {
+			lq.limit = limit
+		}
+	}
+
+	if raw := q.Get("offset"); raw != "" {
+		if offset, err := strconv.Atoi(raw); err == nil && offset >= 0 {
+			lq.offset = offset
+		}
+	}
+
+	if raw := q.Get("cursor"); raw != "" {
+		if offset, ok := decodeCursor(raw); ok {
+			lq.offset = offset
+		}
+	}
+
+	return lq
+}
+
+// filterAndSort applies q's done/q/sort filters to l. todo.List has no
+// methods of its own to extend (it's defined in the external todo
+// package), so these live as free functions here instead.
+func filterAndSort(l todo.List, q listQuery) todo.List {
+	filtered := make(todo.List, 0, len(l))
+	for _, item := range l {
+		if q.done != nil && item.Done != *q.done {
+			continue
+		}
+		if q.q != "" && !strings.Contains(strings.ToLower(item.Task), strings.ToLower(q.q)) {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+
+	switch strings.TrimPrefix(q.sort, "-") {
+	case "task":
+		sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].Task < filtered[j].Task })
+	case "created":
+		sort.SliceStable(filtered, func(i, j int) bool { return filtered[i].CreatedAt.Before(filtered[j].CreatedAt) })
+	}
+
+	if strings.HasPrefix(q.sort, "-") {
+		reverse(filtered)
+	}
+
+	return filtered
+}
+
+func reverse(l todo.List) {
+	for i, j := 0, len(l)-1; i < j; i, j = i+1, j-1 {
+		l[i], l[j] = l[j], l[i]
+	}
+}
+
+// page slices l to the requested limit/offset window and reports the
+// cursor for the next page, or "" once there's nothing left. limit=0 is
+// treated as a count-only request: it never advances the offset, so it
+// never hands back a cursor either -- otherwise a client following
+// Link: rel="next" with limit=0 would loop forever on an identical empty
+// page.
+func page(l todo.List, q listQuery) (results todo.List, nextCursor string) {
+	if q.offset >= len(l) || q.limit == 0 {
+		return todo.List{}, ""
+	}
+
+	end := q.offset + q.limit
+	if end > len(l) {
+		end = len(l)
+	}
+
+	results = l[q.offset:end]
+
+	if end < len(l) {
+		nextCursor = encodeCursor(end)
+	}
+
+	return results, nextCursor
+}
+
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, false
+	}
+
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil || offset < 0 {
+		return 0, false
+	}
+
+	return offset, true
+}
+
+// setNextLink sets an RFC 5988 Link header pointing at the next page,
+// reusing the request's own URL with its cursor replaced.
+func setNextLink(w http.ResponseWriter, r *http.Request, nextCursor string) {
+	if nextCursor == "" {
+		return
+	}
+
+	u := *r.URL
+	q := u.Query()
+	q.Set("cursor", nextCursor)
+	u.RawQuery = q.Encode()
+
+	next := url.URL{Path: u.Path, RawQuery: u.RawQuery}
+	w.Header().Set("Link", fmt.Sprintf(`<%s>; rel="next"`, next.String()))
+}
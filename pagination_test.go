@@ -0,0 +1,65 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Nelwhix/todo"
+)
+
+func TestFilterAndSort(t *testing.T) {
+	l := todo.List{
+		{Task: "Buy milk", Done: false},
+		{Task: "Call mom", Done: true},
+		{Task: "Buy bread", Done: false},
+	}
+
+	done := true
+	filtered := filterAndSort(l, listQuery{done: &done})
+	if len(filtered) != 1 || filtered[0].Task != "Call mom" {
+		t.Fatalf("Expected only the done item, got %+v.", filtered)
+	}
+
+	sorted := filterAndSort(l, listQuery{sort: "task"})
+	if sorted[0].Task != "Buy bread" {
+		t.Errorf("Expected %q sorted first, got %q.", "Buy bread", sorted[0].Task)
+	}
+
+	matched := filterAndSort(l, listQuery{q: "buy"})
+	if len(matched) != 2 {
+		t.Errorf("Expected 2 items matching %q, got %d.", "buy", len(matched))
+	}
+}
+
+func TestPage(t *testing.T) {
+	l := todo.List{{Task: "1"}, {Task: "2"}, {Task: "3"}}
+
+	results, next := page(l, listQuery{limit: 2})
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d.", len(results))
+	}
+	if next == "" {
+		t.Fatal("Expected a next cursor when more results remain.")
+	}
+
+	offset, ok := decodeCursor(next)
+	if !ok || offset != 2 {
+		t.Errorf("Expected cursor to encode offset 2, got %d (ok=%v).", offset, ok)
+	}
+
+	results, next = page(l, listQuery{limit: 2, offset: offset})
+	if len(results) != 1 || next != "" {
+		t.Errorf("Expected the last item with no further cursor, got %d results, next=%q.", len(results), next)
+	}
+}
+
+func TestPageZeroLimit(t *testing.T) {
+	l := todo.List{{Task: "1"}, {Task: "2"}, {Task: "3"}}
+
+	results, next := page(l, listQuery{limit: 0})
+	if len(results) != 0 {
+		t.Errorf("Expected 0 results for limit=0, got %d.", len(results))
+	}
+	if next != "" {
+		t.Errorf("Expected no next cursor for limit=0 (it never advances the offset), got %q.", next)
+	}
+}
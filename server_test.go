@@ -57,6 +57,20 @@ func setupAPI(t *testing.T) (string, func()) {
 	}
 }
 
+// currentETag fetches the current ETag for /todo/{id}, for tests that
+// need to exercise the If-Match precondition required by PATCH/DELETE.
+func currentETag(t *testing.T, url string, id int) string {
+	t.Helper()
+
+	r, err := http.Get(fmt.Sprintf("%s/todo/%d", url, id))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+
+	return r.Header.Get("ETag")
+}
+
 func TestGet(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -212,6 +226,7 @@ func TestDelete(t *testing.T) {
 		if err != nil {
 			t.Fatal(err)
 		}
+		req.Header.Set("If-Match", currentETag(t, url, 1))
 		r, err := http.DefaultClient.Do(req)
 
 		if err != nil {
@@ -256,10 +271,11 @@ func TestComplete(t *testing.T) {
 	t.Run("Complete", func(t *testing.T) {
 		u := fmt.Sprintf("%s/todo/1?complete", url)
 		req, err := http.NewRequest(http.MethodPatch, u, nil)
-	
+
 		if err != nil {
 			t.Fatal(err)
 		}
+		req.Header.Set("If-Match", currentETag(t, url, 1))
 		r, err := http.DefaultClient.Do(req)
 	
 		if err != nil {
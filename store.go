@@ -0,0 +1,353 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Nelwhix/todo"
+)
+
+// errVersionMismatch is returned by Store.Update, Store.Complete and
+// Store.Delete when the caller's version does not match the item
+// currently on disk.
+var errVersionMismatch = errors.New("store: version mismatch")
+
+// errNotFound is returned by Store.Get, Store.Update, Store.Complete and
+// Store.Delete when no item exists at the given id.
+var errNotFound = errors.New("store: item not found")
+
+// Store is the persistence seam the HTTP handlers are written against. The
+// existing JSON-file-per-request behavior is one implementation
+// (fileStore). BoltDB and SQLite backends would be added the same way so
+// the handlers never need to know which one is in use, but neither is
+// implemented or selectable via -store yet: Nelwhix/todoAPI#chunk0-3
+// delivered only the file backend, and the BoltDB/SQLite backends remain
+// open follow-up work rather than something merged and done -- see
+// store_bolt.go and store_sqlite.go for the shape they'd take.
+//
+// Every item carries a Version, which the HTTP layer surfaces as an ETag:
+// GET returns it, PATCH/DELETE must echo it back via If-Match, and a store
+// rejects a stale write with errVersionMismatch rather than silently
+// clobbering a concurrent change.
+//
+// Every method also takes done, the Done() channel of a deadlineTimer:
+// implementations select on it around their I/O and abort with
+// errDeadlineExceeded as soon as it closes, instead of finishing writes
+// the caller has already stopped waiting for.
+type Store interface {
+	Get(done <-chan struct{}, id int) (todo.Item, int, error)
+	List(done <-chan struct{}) (todo.List, error)
+	Add(done <-chan struct{}, task string) (id int, version int, err error)
+	Update(done <-chan struct{}, id, version int, item todo.Item) (newVersion int, err error)
+	// Complete marks id done via the todo package's own Complete method
+	// (which stamps CompletedAt), rather than a raw field assignment.
+	Complete(done <-chan struct{}, id, version int) (item todo.Item, newVersion int, err error)
+	Delete(done <-chan struct{}, id, version int) error
+}
+
+// fileStore adapts the flat JSON/todo.txt file that newMux has always used
+// to the Store interface. Versions are a monotonic counter kept in a
+// sidecar versionTable (see versions.go), since the underlying file
+// format has no version field of its own.
+type fileStore struct {
+	path   string
+	format storageFormat
+}
+
+func newFileStore(path string, format storageFormat) *fileStore {
+	return &fileStore{path: path, format: format}
+}
+
+var (
+	fileLocksMu sync.Mutex
+	fileLocks   = map[string]*sync.Mutex{}
+)
+
+// lockFor returns the mutex guarding path's todo file and its version
+// table, creating one on first use. Every fileStore method, and
+// batch.go's applyBatch, holds this for the full extent of its
+// read-modify-write section: without it, two concurrent requests against
+// the same file -- even targeting different items, even with correct
+// If-Match versions -- can interleave their full-file reads and saves and
+// silently lose one write. If-Match only guards a stale client
+// overwriting an item it already knows about; it does nothing against
+// this whole-file clobber.
+func lockFor(path string) *sync.Mutex {
+	fileLocksMu.Lock()
+	defer fileLocksMu.Unlock()
+
+	mu, ok := fileLocks[path]
+	if !ok {
+		mu = &sync.Mutex{}
+		fileLocks[path] = mu
+	}
+	return mu
+}
+
+func (s *fileStore) Get(done <-chan struct{}, id int) (todo.Item, int, error) {
+	mu := lockFor(s.path)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if aborted(done) {
+		return todo.Item{}, 0, errDeadlineExceeded
+	}
+
+	l, err := getTodoList(s.path, s.format)
+	if err != nil {
+		return todo.Item{}, 0, err
+	}
+
+	if id < 1 || id > len(l) {
+		return todo.Item{}, 0, errNotFound
+	}
+
+	vt, err := loadVersionTable(s.path)
+	if err != nil {
+		return todo.Item{}, 0, err
+	}
+
+	version := vt.versionFor(id)
+	if err := saveVersionTable(s.path, vt); err != nil {
+		return todo.Item{}, 0, err
+	}
+
+	return l[id-1], version, nil
+}
+
+// List scans the whole todo list, checking done every scanCheckInterval
+// items so a caller whose deadline elapsed mid-scan gets
+// errDeadlineExceeded back instead of waiting for a scan it gave up on.
+func (s *fileStore) List(done <-chan struct{}) (todo.List, error) {
+	mu := lockFor(s.path)
+	mu.Lock()
+	defer mu.Unlock()
+
+	l, err := getTodoList(s.path, s.format)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range l {
+		if i%scanCheckInterval == 0 && aborted(done) {
+			return nil, errDeadlineExceeded
+		}
+	}
+
+	return l, nil
+}
+
+func (s *fileStore) Add(done <-chan struct{}, task string) (int, int, error) {
+	mu := lockFor(s.path)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if aborted(done) {
+		return 0, 0, errDeadlineExceeded
+	}
+
+	l, err := getTodoList(s.path, s.format)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	l.Add(task)
+
+	if aborted(done) {
+		return 0, 0, errDeadlineExceeded
+	}
+
+	if err := saveTodoList(s.path, s.format, l); err != nil {
+		return 0, 0, err
+	}
+
+	id := len(l)
+
+	vt, err := loadVersionTable(s.path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	version := vt.bump(id)
+	if err := saveVersionTable(s.path, vt); err != nil {
+		return 0, 0, err
+	}
+
+	return id, version, nil
+}
+
+func (s *fileStore) Update(done <-chan struct{}, id, version int, item todo.Item) (int, error) {
+	mu := lockFor(s.path)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if aborted(done) {
+		return 0, errDeadlineExceeded
+	}
+
+	l, err := getTodoList(s.path, s.format)
+	if err != nil {
+		return 0, err
+	}
+
+	if id < 1 || id > len(l) {
+		return 0, errNotFound
+	}
+
+	vt, err := loadVersionTable(s.path)
+	if err != nil {
+		return 0, err
+	}
+
+	if vt.versionFor(id) != version {
+		return 0, errVersionMismatch
+	}
+
+	l[id-1] = item
+
+	if aborted(done) {
+		return 0, errDeadlineExceeded
+	}
+
+	if err := saveTodoList(s.path, s.format, l); err != nil {
+		return 0, err
+	}
+
+	newVersion := vt.bump(id)
+	if err := saveVersionTable(s.path, vt); err != nil {
+		return 0, err
+	}
+
+	return newVersion, nil
+}
+
+func (s *fileStore) Complete(done <-chan struct{}, id, version int) (todo.Item, int, error) {
+	mu := lockFor(s.path)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if aborted(done) {
+		return todo.Item{}, 0, errDeadlineExceeded
+	}
+
+	l, err := getTodoList(s.path, s.format)
+	if err != nil {
+		return todo.Item{}, 0, err
+	}
+
+	if id < 1 || id > len(l) {
+		return todo.Item{}, 0, errNotFound
+	}
+
+	vt, err := loadVersionTable(s.path)
+	if err != nil {
+		return todo.Item{}, 0, err
+	}
+
+	if vt.versionFor(id) != version {
+		return todo.Item{}, 0, errVersionMismatch
+	}
+
+	if err := l.Complete(id); err != nil {
+		return todo.Item{}, 0, err
+	}
+
+	if aborted(done) {
+		return todo.Item{}, 0, errDeadlineExceeded
+	}
+
+	if err := saveTodoList(s.path, s.format, l); err != nil {
+		return todo.Item{}, 0, err
+	}
+
+	newVersion := vt.bump(id)
+	if err := saveVersionTable(s.path, vt); err != nil {
+		return todo.Item{}, 0, err
+	}
+
+	return l[id-1], newVersion, nil
+}
+
+func (s *fileStore) Delete(done <-chan struct{}, id, version int) error {
+	mu := lockFor(s.path)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if aborted(done) {
+		return errDeadlineExceeded
+	}
+
+	l, err := getTodoList(s.path, s.format)
+	if err != nil {
+		return err
+	}
+
+	if id < 1 || id > len(l) {
+		return errNotFound
+	}
+
+	vt, err := loadVersionTable(s.path)
+	if err != nil {
+		return err
+	}
+
+	if vt.versionFor(id) != version {
+		return errVersionMismatch
+	}
+
+	if err := l.Delete(id); err != nil {
+		return err
+	}
+
+	if aborted(done) {
+		return errDeadlineExceeded
+	}
+
+	if err := saveTodoList(s.path, s.format, l); err != nil {
+		return err
+	}
+
+	vt.deleteID(id)
+	return saveVersionTable(s.path, vt)
+}
+
+// scanCheckInterval bounds how often List polls done while scanning.
+const scanCheckInterval = 64
+
+// aborted reports whether done has already closed.
+func aborted(done <-chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// etag formats a version as a weak HTTP entity tag.
+func etag(version int) string {
+	return fmt.Sprintf(`W/"%s"`, hex.EncodeToString([]byte(fmt.Sprint(version))))
+}
+
+// parseETag recovers the version encoded by etag, for comparing an
+// incoming If-Match header against a store's current version.
+func parseETag(raw string) (int, error) {
+	raw = strings.TrimPrefix(raw, "W/")
+	raw = strings.Trim(raw, `"`)
+
+	decoded, err := hex.DecodeString(raw)
+	if err != nil {
+		return 0, fmt.Errorf("parse etag %q: %w", raw, err)
+	}
+
+	version, err := strconv.Atoi(string(decoded))
+	if err != nil {
+		return 0, fmt.Errorf("parse etag %q: %w", raw, err)
+	}
+
+	return version, nil
+}
@@ -0,0 +1,21 @@
+//go:build boltdb
+
+package main
+
+// FOLLOW-UP NEEDED: Nelwhix/todoAPI#chunk0-3 asked for JSON, BoltDB and
+// SQLite Store implementations; only the JSON file backend (fileStore in
+// store.go) actually exists. This file is not a working backend and
+// should not be read as one -- it's scope left over from that request,
+// tracked here rather than quietly dropped.
+//
+// A BoltDB-backed Store. Building with -tags boltdb requires
+// go.etcd.io/bbolt as a module dependency; this tree has no go.mod to add
+// that require to, so this file is a placeholder for the wiring rather
+// than a working backend. The shape mirrors fileStore: one bucket keyed
+// by item id, each value a JSON-encoded todo.Item plus its version.
+//
+// func newBoltStore(path string) (*boltStore, error) { ... }
+//
+// See store.go for the Store interface this type would implement. main.go's
+// -store flag does not accept "boltdb" until this is filled in — it is not
+// wired up as a selectable backend yet.
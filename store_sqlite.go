@@ -0,0 +1,22 @@
+//go:build sqlite3
+
+package main
+
+// FOLLOW-UP NEEDED: Nelwhix/todoAPI#chunk0-3 asked for JSON, BoltDB and
+// SQLite Store implementations; only the JSON file backend (fileStore in
+// store.go) actually exists. This file is not a working backend and
+// should not be read as one -- it's scope left over from that request,
+// tracked here rather than quietly dropped.
+//
+// A SQLite-backed Store. Building with -tags sqlite3 requires a
+// database/sql driver such as mattn/go-sqlite3 as a module dependency;
+// this tree has no go.mod to add that require to, so this file is a
+// placeholder for the wiring rather than a working backend. The shape
+// mirrors fileStore: a single `items` table with an integer `version`
+// column, Update/Delete guarded by `WHERE id = ? AND version = ?`.
+//
+// func newSQLiteStore(dsn string) (*sqliteStore, error) { ... }
+//
+// See store.go for the Store interface this type would implement. main.go's
+// -store flag does not accept "sqlite3" until this is filled in — it is not
+// wired up as a selectable backend yet.
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestFileStoreOptimisticConcurrency(t *testing.T) {
+	f, err := os.CreateTemp("", "todostoretest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+
+	s := newFileStore(f.Name(), formatJSON)
+
+	id, version, err := s.Add(nil, "Buy milk")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item, _, err := s.Get(nil, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	item.Done = true
+	if _, err := s.Update(nil, id, version+1, item); !errors.Is(err, errVersionMismatch) {
+		t.Errorf("Expected errVersionMismatch for a stale version, got %v.", err)
+	}
+
+	if _, err := s.Update(nil, id, version, item); err != nil {
+		t.Errorf("Expected update with the current version to succeed, got %v.", err)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/Nelwhix/todo"
+)
+
+// eventType names the lifecycle change a todoEvent describes.
+type eventType string
+
+const (
+	eventCreated   eventType = "created"
+	eventUpdated   eventType = "updated"
+	eventCompleted eventType = "completed"
+	eventDeleted   eventType = "deleted"
+)
+
+// todoEvent is one change published to /todo/stream subscribers.
+type todoEvent struct {
+	ID   int64     `json:"id"`
+	Type eventType `json:"type"`
+	Item todo.Item `json:"item"`
+}
+
+// replayBufferSize bounds how many past events a reconnecting client can
+// recover via Last-Event-ID.
+const replayBufferSize = 100
+
+// subscriberQueueSize is the per-client buffered channel depth. A client
+// that falls behind this far is dropped rather than allowed to block
+// publishers.
+const subscriberQueueSize = 32
+
+// todoBroker fans a stream of todoEvents out to subscribed HTTP clients,
+// keeping a short replay buffer so a client reconnecting with
+// Last-Event-ID doesn't miss events published while it was away.
+type todoBroker struct {
+	mu          sync.Mutex
+	nextID      int64
+	subscribers map[chan todoEvent]struct{}
+	replay      []todoEvent
+}
+
+func newTodoBroker() *todoBroker {
+	return &todoBroker{
+		subscribers: make(map[chan todoEvent]struct{}),
+	}
+}
+
+// publish delivers ev to every current subscriber and appends it to the
+// replay buffer. A subscriber whose queue is already full is dropped:
+// slow consumers must not be allowed to block todo mutations.
+func (b *todoBroker) publish(typ eventType, item todo.Item) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	ev := todoEvent{ID: b.nextID, Type: typ, Item: item}
+
+	b.replay = append(b.replay, ev)
+	if len(b.replay) > replayBufferSize {
+		b.replay = b.replay[len(b.replay)-replayBufferSize:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- ev:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe registers a new client and returns its event channel along
+// with any replay events after lastEventID (0 means no replay). Call the
+// returned unsubscribe func when the client disconnects.
+func (b *todoBroker) subscribe(lastEventID int64) (ch chan todoEvent, replay []todoEvent, unsubscribe func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch = make(chan todoEvent, subscriberQueueSize)
+	b.subscribers[ch] = struct{}{}
+
+	for _, ev := range b.replay {
+		if lastEventID > 0 && ev.ID > lastEventID {
+			replay = append(replay, ev)
+		}
+	}
+
+	unsubscribe = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, replay, unsubscribe
+}
+
+// streamHandler serves /todo/stream as Server-Sent Events.
+func streamHandler(b *todoBroker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			replyWithError(w, http.StatusInternalServerError)
+			return
+		}
+
+		var lastEventID int64
+		if raw := r.Header.Get("Last-Event-ID"); raw != "" {
+			lastEventID, _ = strconv.ParseInt(raw, 10, 64)
+		}
+
+		ch, replay, unsubscribe := b.subscribe(lastEventID)
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		for _, ev := range replay {
+			writeSSE(w, ev)
+		}
+		flusher.Flush()
+
+		for {
+			select {
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				writeSSE(w, ev)
+				flusher.Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
+
+func writeSSE(w http.ResponseWriter, ev todoEvent) {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return
+	}
+
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, payload)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Nelwhix/todo"
+)
+
+func TestTodoBrokerReplay(t *testing.T) {
+	b := newTodoBroker()
+
+	b.publish(eventCreated, todo.Item{Task: "first"})
+	b.publish(eventCreated, todo.Item{Task: "second"})
+
+	_, replay, unsubscribe := b.subscribe(1)
+	defer unsubscribe()
+
+	if len(replay) != 1 {
+		t.Fatalf("Expected 1 replayed event after id 1, got %d.", len(replay))
+	}
+
+	if replay[0].Item.Task != "second" {
+		t.Errorf("Expected replayed event for %q, got %q.", "second", replay[0].Item.Task)
+	}
+}
+
+func TestTodoBrokerDropsSlowSubscriber(t *testing.T) {
+	b := newTodoBroker()
+
+	ch, _, unsubscribe := b.subscribe(0)
+	defer unsubscribe()
+
+	for i := 0; i < subscriberQueueSize+1; i++ {
+		b.publish(eventUpdated, todo.Item{Task: "spam"})
+	}
+
+	if _, ok := b.subscribers[ch]; ok {
+		t.Error("Expected slow subscriber to be dropped once its queue filled up.")
+	}
+}
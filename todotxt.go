@@ -0,0 +1,317 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Nelwhix/todo"
+)
+
+// storageFormat selects how the todo file on disk is read and written.
+type storageFormat string
+
+const (
+	formatJSON    storageFormat = "json"
+	formatTodoTxt storageFormat = "todotxt"
+)
+
+// todoMeta holds the todo.txt fields parsed out of an Item's Task string:
+// priority "(A)", "+project" and "@context" tags, and "key:value" metadata
+// such as "due:2025-01-01". It never mutates the underlying todo.Item.
+type todoMeta struct {
+	Priority string            `json:"priority,omitempty"`
+	Projects []string          `json:"projects,omitempty"`
+	Contexts []string          `json:"contexts,omitempty"`
+	Due      *time.Time        `json:"due,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+var (
+	priorityRe  = regexp.MustCompile(`^\(([A-Z])\)\s+`)
+	projectRe   = regexp.MustCompile(`\+(\S+)`)
+	contextRe   = regexp.MustCompile(`@(\S+)`)
+	metadataRe  = regexp.MustCompile(`(\S+):(\S+)`)
+	dateTokenRe = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2})\s+`)
+)
+
+// isZero reports whether no todo.txt annotation was found at all, so
+// callers can omit an empty meta rather than attach a useless one.
+func (m todoMeta) isZero() bool {
+	return m.Priority == "" && len(m.Projects) == 0 && len(m.Contexts) == 0 && m.Due == nil && len(m.Metadata) == 0
+}
+
+// parseTodoMeta extracts the todo.txt annotations embedded in a task's text.
+func parseTodoMeta(task string) todoMeta {
+	var meta todoMeta
+
+	if m := priorityRe.FindStringSubmatch(task); m != nil {
+		meta.Priority = m[1]
+	}
+
+	for _, m := range projectRe.FindAllStringSubmatch(task, -1) {
+		meta.Projects = append(meta.Projects, m[1])
+	}
+
+	for _, m := range contextRe.FindAllStringSubmatch(task, -1) {
+		meta.Contexts = append(meta.Contexts, m[1])
+	}
+
+	for _, m := range metadataRe.FindAllStringSubmatch(task, -1) {
+		key, value := m[1], m[2]
+		if key == "due" {
+			if due, err := time.Parse("2006-01-02", value); err == nil {
+				meta.Due = &due
+				continue
+			}
+		}
+
+		if meta.Metadata == nil {
+			meta.Metadata = make(map[string]string)
+		}
+		meta.Metadata[key] = value
+	}
+
+	return meta
+}
+
+// getTodoList loads the todo list from todoFile, translating from the
+// todo.txt format first when format is formatTodoTxt.
+func getTodoList(todoFile string, format storageFormat) (todo.List, error) {
+	if format != formatTodoTxt {
+		l := todo.List{}
+		err := l.Get(todoFile)
+		return l, err
+	}
+
+	f, err := os.Open(todoFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return todo.List{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	return decodeTodoTxt(f)
+}
+
+// saveTodoList persists l to todoFile, translating to the todo.txt format
+// first when format is formatTodoTxt.
+func saveTodoList(todoFile string, format storageFormat, l todo.List) error {
+	if format != formatTodoTxt {
+		return l.Save(todoFile)
+	}
+
+	f, err := os.Create(todoFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return encodeTodoTxt(f, l)
+}
+
+// decodeTodoTxt reads one task per line in todo.txt's plain-text format.
+// A leading "x " marks a line done, per the spec followed by the
+// completion date and then the creation date, each "YYYY-MM-DD"; an
+// incomplete line may have just a creation date, after any priority
+// prefix. Priority, projects, contexts and metadata stay inline in the
+// task text and are recovered on demand via parseTodoMeta, but dates are
+// structural (they map onto CreatedAt/CompletedAt) and are stripped out
+// here rather than left in Task.
+func decodeTodoTxt(r io.Reader) (todo.List, error) {
+	l := todo.List{}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		done := strings.HasPrefix(line, "x ")
+		if done {
+			line = strings.TrimPrefix(line, "x ")
+		}
+
+		var createdAt, completedAt time.Time
+
+		switch {
+		case done:
+			if t, rest, ok := stripLeadingDate(line); ok {
+				completedAt = t
+				line = rest
+				if t, rest, ok := stripLeadingDate(line); ok {
+					createdAt = t
+					line = rest
+				}
+			}
+		default:
+			prefix := ""
+			rest := line
+			if m := priorityRe.FindStringSubmatch(line); m != nil {
+				prefix, rest = m[0], line[len(m[0]):]
+			}
+			if t, tail, ok := stripLeadingDate(rest); ok {
+				createdAt = t
+				line = prefix + tail
+			}
+		}
+
+		l.Add(line)
+		id := len(l)
+
+		if !createdAt.IsZero() {
+			l[id-1].CreatedAt = createdAt
+		}
+
+		if done {
+			if err := l.Complete(id); err != nil {
+				return nil, fmt.Errorf("decode todo.txt: %w", err)
+			}
+			if !completedAt.IsZero() {
+				l[id-1].CompletedAt = completedAt
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// stripLeadingDate reports whether s starts with a "YYYY-MM-DD " date
+// token, returning the parsed date and the remainder with it removed.
+func stripLeadingDate(s string) (time.Time, string, bool) {
+	m := dateTokenRe.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, s, false
+	}
+
+	t, err := time.Parse("2006-01-02", m[1])
+	if err != nil {
+		return time.Time{}, s, false
+	}
+
+	return t, s[len(m[0]):], true
+}
+
+// encodeTodoTxt writes l as one todo.txt line per item.
+func encodeTodoTxt(w io.Writer, l todo.List) error {
+	bw := bufio.NewWriter(w)
+
+	for _, item := range l {
+		if _, err := fmt.Fprintln(bw, todoTxtLine(item)); err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// todoTxtLine formats one item in todo.txt's plain-text format, writing
+// CompletedAt/CreatedAt back out as the standard date prefix so they
+// round-trip instead of being silently dropped.
+func todoTxtLine(item todo.Item) string {
+	const dateFormat = "2006-01-02"
+
+	if item.Done {
+		line := "x"
+		if !item.CompletedAt.IsZero() {
+			line += " " + item.CompletedAt.Format(dateFormat)
+			if !item.CreatedAt.IsZero() {
+				line += " " + item.CreatedAt.Format(dateFormat)
+			}
+		}
+		return line + " " + item.Task
+	}
+
+	if item.CreatedAt.IsZero() {
+		return item.Task
+	}
+
+	// Priority, if present, stays ahead of the creation date per the
+	// todo.txt spec: "(A) 2021-01-01 Call Mom", not the reverse.
+	if m := priorityRe.FindStringSubmatch(item.Task); m != nil {
+		return m[0] + item.CreatedAt.Format(dateFormat) + " " + item.Task[len(m[0]):]
+	}
+
+	return item.CreatedAt.Format(dateFormat) + " " + item.Task
+}
+
+// todoTxtFilters are the query parameters that filter a todo.txt-annotated
+// list: project, context, priority and due_before.
+type todoTxtFilters struct {
+	project  string
+	context  string
+	priority string
+	dueBefore *time.Time
+}
+
+// apply returns the subset of l whose parsed todo.txt metadata matches f.
+// A zero-value todoTxtFilters matches everything.
+func (f todoTxtFilters) apply(l todo.List) todo.List {
+	if f.project == "" && f.context == "" && f.priority == "" && f.dueBefore == nil {
+		return l
+	}
+
+	filtered := make(todo.List, 0, len(l))
+	for _, item := range l {
+		meta := parseTodoMeta(item.Task)
+
+		if f.project != "" && !containsString(meta.Projects, f.project) {
+			continue
+		}
+		if f.context != "" && !containsString(meta.Contexts, f.context) {
+			continue
+		}
+		if f.priority != "" && meta.Priority != f.priority {
+			continue
+		}
+		if f.dueBefore != nil && (meta.Due == nil || !meta.Due.Before(*f.dueBefore)) {
+			continue
+		}
+
+		filtered = append(filtered, item)
+	}
+
+	return filtered
+}
+
+// todoResponseItem is one item in a todoResponse: the todo.Item fields the
+// client already sees, plus its parsed todo.txt annotations when any are
+// present. Without this, a client can filter by ?project=/?context=/
+// ?priority=/?due_before= but only ever gets the raw Task string back,
+// annotations and all, with nothing structured to read them from.
+type todoResponseItem struct {
+	todo.Item
+	Meta *todoMeta `json:"meta,omitempty"`
+}
+
+// toResponseItems wraps each item in l with its parsed todo.txt metadata.
+func toResponseItems(l todo.List) []todoResponseItem {
+	items := make([]todoResponseItem, len(l))
+	for i, item := range l {
+		items[i] = todoResponseItem{Item: item}
+		if meta := parseTodoMeta(item.Task); !meta.isZero() {
+			items[i].Meta = &meta
+		}
+	}
+	return items
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
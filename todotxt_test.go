@@ -0,0 +1,78 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTodoMeta(t *testing.T) {
+	testCases := []struct {
+		name         string
+		task         string
+		expPriority  string
+		expProjects  []string
+		expContexts  []string
+		expHasDue    bool
+	}{
+		{
+			name: "plain task has no metadata",
+			task: "Buy milk",
+		},
+		{
+			name:        "priority, project, context and due date",
+			task:        "(A) Call Mom +Family @Phone due:2025-01-01",
+			expPriority: "A",
+			expProjects: []string{"Family"},
+			expContexts: []string{"Phone"},
+			expHasDue:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			meta := parseTodoMeta(tc.task)
+
+			if meta.Priority != tc.expPriority {
+				t.Errorf("Expected priority %q, got %q.", tc.expPriority, meta.Priority)
+			}
+
+			if len(meta.Projects) != len(tc.expProjects) {
+				t.Fatalf("Expected %d projects, got %d.", len(tc.expProjects), len(meta.Projects))
+			}
+
+			if len(meta.Contexts) != len(tc.expContexts) {
+				t.Fatalf("Expected %d contexts, got %d.", len(tc.expContexts), len(meta.Contexts))
+			}
+
+			if (meta.Due != nil) != tc.expHasDue {
+				t.Errorf("Expected due date present: %v, got: %v.", tc.expHasDue, meta.Due != nil)
+			}
+		})
+	}
+}
+
+func TestDecodeEncodeTodoTxt(t *testing.T) {
+	input := "x Buy milk\n(A) Call Mom +Family @Phone\n"
+
+	l, err := decodeTodoTxt(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(l) != 2 {
+		t.Fatalf("Expected 2 items, got %d.", len(l))
+	}
+
+	if !l[0].Done {
+		t.Error("Expected first item to be marked done.")
+	}
+
+	var out strings.Builder
+	if err := encodeTodoTxt(&out, l); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(out.String(), "Call Mom") {
+		t.Errorf("Expected encoded output to contain task text, got %q.", out.String())
+	}
+}
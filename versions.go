@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// versionTable is fileStore's sidecar record of each item's monotonic
+// version, keyed by the item's (positional) id. It lives next to the
+// main todo file because todo.Item itself has no version field.
+//
+// Ids here are positions in the todo.List, same as everywhere else in
+// this package: deleting id N shifts every id above it down by one, so
+// deleteID below renumbers the table to match rather than leaving stale
+// entries pointing at the wrong item.
+type versionTable struct {
+	Next     int         `json:"next"`
+	Versions map[int]int `json:"versions"`
+}
+
+func versionsPath(path string) string {
+	return path + ".versions.json"
+}
+
+func loadVersionTable(path string) (versionTable, error) {
+	data, err := os.ReadFile(versionsPath(path))
+	if os.IsNotExist(err) {
+		return versionTable{Next: 1, Versions: map[int]int{}}, nil
+	} else if err != nil {
+		return versionTable{}, err
+	}
+
+	var vt versionTable
+	if err := json.Unmarshal(data, &vt); err != nil {
+		return versionTable{}, err
+	}
+
+	if vt.Versions == nil {
+		vt.Versions = map[int]int{}
+	}
+
+	return vt, nil
+}
+
+func saveVersionTable(path string, vt versionTable) error {
+	data, err := json.Marshal(vt)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(versionsPath(path), data, 0o644)
+}
+
+// versionFor returns id's current version, assigning it one lazily (for
+// an item that existed before the version table did) without treating
+// that first read as a write.
+func (vt *versionTable) versionFor(id int) int {
+	if v, ok := vt.Versions[id]; ok {
+		return v
+	}
+
+	v := vt.Next
+	vt.Next++
+	vt.Versions[id] = v
+	return v
+}
+
+// bump assigns id a fresh version, for use after any write to that item.
+func (vt *versionTable) bump(id int) int {
+	v := vt.Next
+	vt.Next++
+	vt.Versions[id] = v
+	return v
+}
+
+// deleteID removes id's entry and shifts every higher id down by one, to
+// track the positional renumbering a todo.List.Delete(id) causes.
+func (vt *versionTable) deleteID(id int) {
+	delete(vt.Versions, id)
+
+	shifted := make(map[int]int, len(vt.Versions))
+	for existingID, v := range vt.Versions {
+		if existingID > id {
+			shifted[existingID-1] = v
+		} else {
+			shifted[existingID] = v
+		}
+	}
+	vt.Versions = shifted
+}